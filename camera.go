@@ -0,0 +1,191 @@
+package main
+
+import (
+	"math"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// direction flags used by Camera.ProcessKeyboard
+const (
+	cameraForward = iota
+	cameraBackward
+	cameraLeft
+	cameraRight
+)
+
+const (
+	defaultYaw         = -90.0
+	defaultPitch       = 0.0
+	defaultSpeed       = 2.5
+	defaultSensitivity = 0.1
+	defaultFov         = 45.0
+)
+
+// Camera is a free-fly Euler-angle camera, more or less the one from
+// every LearnOpenGL tutorial ever written.
+type Camera struct {
+	Position mgl32.Vec3
+	Front    mgl32.Vec3
+	Up       mgl32.Vec3
+	Right    mgl32.Vec3
+	WorldUp  mgl32.Vec3
+
+	Yaw   float32
+	Pitch float32
+
+	MovementSpeed    float32
+	MouseSensitivity float32
+	Fov              float32
+}
+
+// NewCamera builds a Camera sitting at position, looking down -Z.
+func NewCamera(position mgl32.Vec3) *Camera {
+	c := &Camera{
+		Position:         position,
+		Front:            mgl32.Vec3{0, 0, -1},
+		WorldUp:          mgl32.Vec3{0, 1, 0},
+		Yaw:              defaultYaw,
+		Pitch:            defaultPitch,
+		MovementSpeed:    defaultSpeed,
+		MouseSensitivity: defaultSensitivity,
+		Fov:              defaultFov,
+	}
+	c.updateVectors()
+	return c
+}
+
+// ViewMatrix returns the view matrix for the camera's current position
+// and orientation.
+func (c *Camera) ViewMatrix() mgl32.Mat4 {
+	return mgl32.LookAtV(c.Position, c.Position.Add(c.Front), c.Up)
+}
+
+// ProcessKeyboard moves the camera in direction, scaled by deltaTime so
+// movement speed doesn't depend on framerate.
+func (c *Camera) ProcessKeyboard(direction int, deltaTime float64) {
+	velocity := c.MovementSpeed * float32(deltaTime)
+	switch direction {
+	case cameraForward:
+		c.Position = c.Position.Add(c.Front.Mul(velocity))
+	case cameraBackward:
+		c.Position = c.Position.Sub(c.Front.Mul(velocity))
+	case cameraLeft:
+		c.Position = c.Position.Sub(c.Right.Mul(velocity))
+	case cameraRight:
+		c.Position = c.Position.Add(c.Right.Mul(velocity))
+	}
+}
+
+// ProcessMouseMovement applies a raw (unscaled) mouse delta to yaw/pitch.
+func (c *Camera) ProcessMouseMovement(xoffset, yoffset float32, constrainPitch bool) {
+	xoffset *= c.MouseSensitivity
+	yoffset *= c.MouseSensitivity
+
+	c.Yaw += xoffset
+	c.Pitch += yoffset
+
+	if constrainPitch {
+		if c.Pitch > 89.0 {
+			c.Pitch = 89.0
+		}
+		if c.Pitch < -89.0 {
+			c.Pitch = -89.0
+		}
+	}
+
+	c.updateVectors()
+}
+
+// ProcessMouseScroll zooms the camera by adjusting its field of view.
+func (c *Camera) ProcessMouseScroll(yoffset float32) {
+	c.Fov -= yoffset
+	if c.Fov < 1.0 {
+		c.Fov = 1.0
+	}
+	if c.Fov > 45.0 {
+		c.Fov = 45.0
+	}
+}
+
+// updateVectors recomputes Front/Right/Up from the current yaw and pitch.
+func (c *Camera) updateVectors() {
+	yaw := float64(mgl32.DegToRad(c.Yaw))
+	pitch := float64(mgl32.DegToRad(c.Pitch))
+
+	front := mgl32.Vec3{
+		float32(math.Cos(pitch) * math.Cos(yaw)),
+		float32(math.Sin(pitch)),
+		float32(math.Cos(pitch) * math.Sin(yaw)),
+	}
+	c.Front = front.Normalize()
+	c.Right = c.Front.Cross(c.WorldUp).Normalize()
+	c.Up = c.Right.Cross(c.Front).Normalize()
+}
+
+// cameraState bundles the bits of mutable, callback-driven input state
+// that the GLFW camera callbacks need to close over.
+type cameraState struct {
+	camera     *Camera
+	keys       [glfw.KeyLast + 1]bool
+	firstMouse bool
+	lastX      float64
+	lastY      float64
+}
+
+func newCameraState(camera *Camera) *cameraState {
+	return &cameraState{camera: camera, firstMouse: true}
+}
+
+// keyCallback just tracks which keys are currently held; actual movement
+// happens once a frame in processInput so it can be scaled by deltaTime.
+func (s *cameraState) keyCallback(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
+	if key < 0 || int(key) >= len(s.keys) {
+		return
+	}
+	switch action {
+	case glfw.Press:
+		s.keys[key] = true
+	case glfw.Release:
+		s.keys[key] = false
+	}
+	if key == glfw.KeyEscape && action == glfw.Press {
+		w.SetShouldClose(true)
+	}
+}
+
+// cursorPosCallback turns raw cursor movement into a mouse-look delta.
+func (s *cameraState) cursorPosCallback(w *glfw.Window, xpos, ypos float64) {
+	if s.firstMouse {
+		s.lastX, s.lastY = xpos, ypos
+		s.firstMouse = false
+	}
+
+	xoffset := xpos - s.lastX
+	yoffset := s.lastY - ypos // reversed: y-coordinates go from bottom to top
+	s.lastX, s.lastY = xpos, ypos
+
+	s.camera.ProcessMouseMovement(float32(xoffset), float32(yoffset), true)
+}
+
+// scrollCallback zooms the camera in and out.
+func (s *cameraState) scrollCallback(w *glfw.Window, xoff, yoff float64) {
+	s.camera.ProcessMouseScroll(float32(yoff))
+}
+
+// processInput walks the held-key state and moves the camera accordingly.
+func (s *cameraState) processInput(deltaTime float64) {
+	if s.keys[glfw.KeyW] {
+		s.camera.ProcessKeyboard(cameraForward, deltaTime)
+	}
+	if s.keys[glfw.KeyS] {
+		s.camera.ProcessKeyboard(cameraBackward, deltaTime)
+	}
+	if s.keys[glfw.KeyA] {
+		s.camera.ProcessKeyboard(cameraLeft, deltaTime)
+	}
+	if s.keys[glfw.KeyD] {
+		s.camera.ProcessKeyboard(cameraRight, deltaTime)
+	}
+}
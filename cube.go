@@ -1,20 +1,20 @@
 package main
 
 import (
-	"fmt"
-	"image"
-	"image/draw"
-	_ "image/jpeg"
-	_ "image/png"
-	"io/ioutil"
 	"log"
+	"math"
 	"os"
 	"runtime"
-	"strings"
 
 	"github.com/go-gl/gl/v3.3-core/gl"
 	"github.com/go-gl/glfw/v3.2/glfw"
 	"github.com/go-gl/mathgl/mgl32"
+	"github.com/gwowen/go-3D-cube/framebuffer"
+	"github.com/gwowen/go-3D-cube/light"
+	"github.com/gwowen/go-3D-cube/mesh"
+	"github.com/gwowen/go-3D-cube/postfx"
+	"github.com/gwowen/go-3D-cube/scene"
+	"github.com/gwowen/go-3D-cube/shader"
 )
 
 const windowWidth = 800
@@ -52,6 +52,15 @@ func main() {
 
 	window.MakeContextCurrent()
 
+	// free-fly camera: hide the cursor and route keyboard/mouse/scroll
+	// input through cameraState so movement is WASD + mouse-look
+	camera := NewCamera(mgl32.Vec3{0, 0, 3})
+	input := newCameraState(camera)
+	window.SetInputMode(glfw.CursorMode, glfw.CursorDisabled)
+	window.SetKeyCallback(input.keyCallback)
+	window.SetCursorPosCallback(input.cursorPosCallback)
+	window.SetScrollCallback(input.scrollCallback)
+
 	// Initialize opengl (glow)
 	if err := gl.Init(); err != nil {
 		panic(err)
@@ -65,270 +74,141 @@ func main() {
 	// version := gl.GoStr(gl.GetString(gl.VERSION))
 	// fmt.Println("OpenGL version", version)
 
-	// load vertex and frag shaders
-	program, err := shaderProgFromFile("shader.vert", "shader.frag")
+	// load vertex and frag shaders, watching them for hot reload
+	shaders, err := shader.NewManager()
 	if err != nil {
 		panic(err)
 	}
+	defer shaders.Close()
 
-	// set program to be used
-	gl.UseProgram(program)
-
-	// create vertex array object and index buffers
-	var vao uint32
-	gl.GenVertexArrays(1, &vao)
-	gl.BindVertexArray(vao)
-
-	var vbo uint32
-	gl.GenBuffers(1, &vbo)
-	gl.BindBuffer(gl.ARRAY_BUFFER, vbo)
-	gl.BufferData(gl.ARRAY_BUFFER, len(cubeVertices)*4, gl.Ptr(cubeVertices), gl.STATIC_DRAW)
-
-	// position attribute
-	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, 5*4, gl.PtrOffset(0))
-	gl.EnableVertexAttribArray(0)
-	// texture coord attribute
-	gl.VertexAttribPointer(1, 2, gl.FLOAT, false, 5*4, gl.PtrOffset(3*4))
-	gl.EnableVertexAttribArray(1)
-
-	// set uniforms for textures 1 and 2
-	textureUniform1 := gl.GetUniformLocation(program, gl.Str("texture1\x00"))
-	gl.Uniform1i(textureUniform1, 0)
-
-	textureUniform2 := gl.GetUniformLocation(program, gl.Str("texture2\x00"))
-	gl.Uniform1i(textureUniform2, 1)
-
-	// load textures
-	texture1, err := loadTexture("container.jpg")
+	program, err := shaders.Load("shader.vert", "shader.frag")
 	if err != nil {
-		log.Fatalln(err)
+		panic(err)
 	}
 
-	texture2, err := loadTexture("awesomeface.png")
+	postProgram, err := shaders.Load("post.vert", "post.frag")
 	if err != nil {
-		log.Fatalln(err)
+		panic(err)
 	}
 
-	// bit different to C++ version, but never mind
-	angle := 0.0
-	previousTime := glfw.GetTime()
-
-	for !window.ShouldClose() {
-		gl.ClearColor(0.2, 0.3, 0.3, 1.0)
-		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
-
-		// update
-		time := glfw.GetTime()
-		deltaTime := time - previousTime
-		previousTime = time
-
-		gl.ActiveTexture(gl.TEXTURE0)
-		gl.BindTexture(gl.TEXTURE_2D, texture1)
-		gl.ActiveTexture(gl.TEXTURE1)
-		gl.BindTexture(gl.TEXTURE_2D, texture2)
-
-		gl.UseProgram(program)
-
-		angle += deltaTime
-		// These are a little bit different from
-		// glm, but close enough that I can easily
-		// translate...
-		model := mgl32.HomogRotate3D(float32(angle), mgl32.Vec3{0.5, 1.0, 0.0})
-		view := mgl32.Translate3D(0.0, 0.0, -3.0)
-		projection := mgl32.Perspective(mgl32.DegToRad(45.0), float32(windowWidth)/windowHeight, 0.1, 100)
-
-		// retrieve matrix uniform location
-		modelLoc := gl.GetUniformLocation(program, gl.Str("model\x00"))
-		viewLoc := gl.GetUniformLocation(program, gl.Str("view\x00"))
-		// pass them to shaders
-		// matrix layout is a bit different as well, but again
-		// close enough to be able to make an educated guess...
-		gl.UniformMatrix4fv(modelLoc, 1, false, &model[0])
-		gl.UniformMatrix4fv(viewLoc, 1, false, &view[0])
-		// get uniform for projection
-		projLoc := gl.GetUniformLocation(program, gl.Str("projection\x00"))
-		gl.UniformMatrix4fv(projLoc, 1, false, &projection[0])
-		// bind and draw
-		gl.BindVertexArray(vao)
-		gl.DrawArrays(gl.TRIANGLES, 0, 6*2*3)
-		window.SwapBuffers()
-		glfw.PollEvents()
-	}
-}
+	// set program to be used
+	gl.UseProgram(program.Handle())
 
-func shaderProgFromFile(vertShaderPath, fragShaderPath string) (uint32, error) {
-	// read vert shader from file raw
-	vertSourceRaw, err := ioutil.ReadFile(vertShaderPath)
+	// scene renders into an off-screen FBO first, then a post-processing
+	// chain draws it to the window so effects can be stacked
+	sceneFBO, err := framebuffer.NewColorDepth(windowWidth, windowHeight)
 	if err != nil {
-		log.Fatal(err)
+		panic(err)
 	}
+	defer sceneFBO.Delete()
 
-	// and turn them back into strings? std::string oh
-	// how I miss thee
-	vertSource := string(vertSourceRaw)
-
-	// do the same for frag shader as above
-	fragSourceRaw, err := ioutil.ReadFile(fragShaderPath)
+	postChain, err := postfx.NewChain(postProgram, windowWidth, windowHeight)
 	if err != nil {
-		log.Fatal(err)
+		panic(err)
 	}
+	defer postChain.Delete()
 
-	fragSource := string(fragSourceRaw)
-
-	// compile vert and frag shader
-	fragShader, err := compileShader(vertSource, gl.VERTEX_SHADER)
-
-	if err != nil {
-		return 0, err
+	passes := []postfx.Pass{
+		{Effect: postfx.EffectKernel, Kernel: postfx.SharpenKernel, KernelOffset: 1.0 / 300.0},
+		{Effect: postfx.EffectGammaCorrect, Gamma: 2.2},
 	}
 
-	vertShader, err := compileShader(fragSource, gl.FRAGMENT_SHADER)
-
-	if err != nil {
-		return 0, err
+	// model file to render is given on the command line, so this isn't
+	// stuck drawing a cube forever
+	modelPath := "cube.obj"
+	if len(os.Args) > 1 {
+		modelPath = os.Args[1]
 	}
 
-	// create the program, attach shaders
-	// and link
-	program := gl.CreateProgram()
-
-	gl.AttachShader(program, vertShader)
-	gl.AttachShader(program, fragShader)
-	gl.LinkProgram(program)
-
-	// check status for errors
-	var status int32
-	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
-
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
-
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(log))
-
-		return 0, fmt.Errorf("failed to link program: %v", log)
+	obj, err := mesh.LoadOBJ(modelPath)
+	if err != nil {
+		log.Fatalln(err)
 	}
 
-	gl.DeleteShader(vertShader)
-	gl.DeleteShader(fragShader)
+	// two nodes sharing the same mesh but with independent transforms
+	// and materials, to actually exercise the scene graph instead of
+	// just plumbing it
+	node := scene.NewNode(obj, &scene.Material{
+		Program:    program,
+		DiffuseMap: obj.Texture(),
+		Shininess:  32,
+	})
+	node2 := scene.NewNode(obj, &scene.Material{
+		Program:    program,
+		DiffuseMap: obj.Texture(),
+		Shininess:  32,
+	})
+	sceneGraph := scene.NewScene()
+	sceneGraph.Add(node)
+	sceneGraph.Add(node2)
+
+	// one directional "sun", plus a point light that orbits the model
+	// so Blinn-Phong shading is easy to eyeball
+	lights := light.NewManager()
+	lights.Directional = &light.DirectionalLight{
+		Direction: mgl32.Vec3{-0.2, -1.0, -0.3},
+		Ambient:   mgl32.Vec3{0.05, 0.05, 0.05},
+		Diffuse:   mgl32.Vec3{0.4, 0.4, 0.4},
+		Specular:  mgl32.Vec3{0.5, 0.5, 0.5},
+	}
+	orbitLight := light.PointLight{
+		Ambient:     mgl32.Vec3{0.05, 0.05, 0.05},
+		Diffuse:     mgl32.Vec3{0.8, 0.8, 0.8},
+		Specular:    mgl32.Vec3{1.0, 1.0, 1.0},
+		Attenuation: light.Attenuation{Constant: 1.0, Linear: 0.09, Quadratic: 0.032},
+	}
+	lights.Points = append(lights.Points, orbitLight)
 
-	return program, nil
-}
-
-func compileShader(source string, shaderType uint32) (uint32, error) {
-	// compiles shader
-	shader := gl.CreateShader(shaderType)
-
-	csources, free := gl.Strs(source)
-	gl.ShaderSource(shader, 1, csources, nil)
-	free()
-	gl.CompileShader(shader)
+	// bit different to C++ version, but never mind
+	angle := 0.0
+	previousTime := glfw.GetTime()
 
-	var status int32
-	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
-	if status == gl.FALSE {
-		var logLength int32
-		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
+	for !window.ShouldClose() {
+		// pick up any shader reloads the watcher queued; this runs on
+		// the render loop's locked GL thread, which is the only safe
+		// place to touch the GL context
+		shaders.Poll()
 
-		log := strings.Repeat("\x00", int(logLength+1))
-		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(log))
+		// update
+		time := glfw.GetTime()
+		deltaTime := time - previousTime
+		previousTime = time
 
-		return 0, fmt.Errorf("failed to compile %v: %v", source, log)
-	}
+		input.processInput(deltaTime)
 
-	return shader, nil
-}
+		angle += deltaTime
+		// These are a little bit different from
+		// glm, but close enough that I can easily
+		// translate...
+		node.Transform = mgl32.Translate3D(-1.5, 0, 0).Mul4(mgl32.HomogRotate3D(float32(angle), mgl32.Vec3{0.5, 1.0, 0.0}))
+		node2.Transform = mgl32.Translate3D(1.5, 0, 0).Mul4(mgl32.HomogRotate3D(float32(angle)*-0.6, mgl32.Vec3{0.0, 1.0, 0.3}))
+		view := camera.ViewMatrix()
+		projection := mgl32.Perspective(mgl32.DegToRad(camera.Fov), float32(windowWidth)/windowHeight, 0.1, 100)
+
+		orbitRadius := float32(2.0)
+		lights.Points[0].Position = mgl32.Vec3{
+			orbitRadius * float32(math.Cos(angle)),
+			1.0,
+			orbitRadius * float32(math.Sin(angle)),
+		}
+		viewPos := map[string]interface{}{"viewPos": camera.Position}
+		node.Material.Uniforms = viewPos
+		node2.Material.Uniforms = viewPos
+
+		// pass 1: render the scene into the off-screen FBO
+		sceneFBO.Bind()
+		gl.ClearColor(0.2, 0.3, 0.3, 1.0)
+		gl.Clear(gl.COLOR_BUFFER_BIT | gl.DEPTH_BUFFER_BIT)
 
-func loadTexture(file string) (uint32, error) {
-	// does what it says on the tin
-	// reads from same directory... probably should
-	// make it a bit more versatile but meh
-	imgFile, err := os.Open(file)
-	if err != nil {
-		return 0, fmt.Errorf("Texture %q not found: %v", file, err)
-	}
+		gl.UseProgram(program.Handle())
+		lights.Upload(program)
+		sceneGraph.Draw(view, projection)
 
-	// I actually really like this compared
-	// with C++, don't need to use SOIL or
-	// stb_image to load things - just use image!
-	img, _, err := image.Decode(imgFile)
-	if err != nil {
-		return 0, err
-	}
+		// pass 2: run the post-processing chain over the FBO's color
+		// buffer and present the result to the window
+		postChain.Draw(sceneFBO.ColorTexture(), passes, windowWidth, windowHeight)
 
-	rgba := image.NewRGBA(img.Bounds())
-	if rgba.Stride != rgba.Rect.Size().X*4 {
-		return 0, fmt.Errorf("Unsupported stride")
+		window.SwapBuffers()
+		glfw.PollEvents()
 	}
-	draw.Draw(rgba, rgba.Bounds(), img, image.Point{0, 0}, draw.Src)
-
-	var texture uint32
-	// generate and bind texture...
-	// image rocks, gives really easy
-	// access to the file's data!
-	gl.GenTextures(1, &texture)
-	gl.ActiveTexture(gl.TEXTURE0)
-	gl.BindTexture(gl.TEXTURE_2D, texture)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
-	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
-	gl.TexImage2D(
-		gl.TEXTURE_2D,
-		0,
-		gl.RGBA,
-		int32(rgba.Rect.Size().X),
-		int32(rgba.Rect.Size().Y),
-		0,
-		gl.RGBA,
-		gl.UNSIGNED_BYTE,
-		gl.Ptr(rgba.Pix))
-
-	return texture, nil
-}
-
-var cubeVertices = []float32{
-	// x   y     z     u     v
-	-0.5, -0.5, -0.5, 0.0, 0.0,
-	0.5, -0.5, -0.5, 1.0, 0.0,
-	0.5, 0.5, -0.5, 1.0, 1.0,
-	0.5, 0.5, -0.5, 1.0, 1.0,
-	-0.5, 0.5, -0.5, 0.0, 1.0,
-	-0.5, -0.5, -0.5, 0.0, 0.0,
-
-	-0.5, -0.5, 0.5, 0.0, 0.0,
-	0.5, -0.5, 0.5, 1.0, 0.0,
-	0.5, 0.5, 0.5, 1.0, 1.0,
-	0.5, 0.5, 0.5, 1.0, 1.0,
-	-0.5, 0.5, 0.5, 0.0, 1.0,
-	-0.5, -0.5, 0.5, 0.0, 0.0,
-
-	-0.5, 0.5, 0.5, 1.0, 0.0,
-	-0.5, 0.5, -0.5, 1.0, 1.0,
-	-0.5, -0.5, -0.5, 0.0, 1.0,
-	-0.5, -0.5, -0.5, 0.0, 1.0,
-	-0.5, -0.5, 0.5, 0.0, 0.0,
-	-0.5, 0.5, 0.5, 1.0, 0.0,
-
-	0.5, 0.5, 0.5, 1.0, 0.0,
-	0.5, 0.5, -0.5, 1.0, 1.0,
-	0.5, -0.5, -0.5, 0.0, 1.0,
-	0.5, -0.5, -0.5, 0.0, 1.0,
-	0.5, -0.5, 0.5, 0.0, 0.0,
-	0.5, 0.5, 0.5, 1.0, 0.0,
-
-	-0.5, -0.5, -0.5, 0.0, 1.0,
-	0.5, -0.5, -0.5, 1.0, 1.0,
-	0.5, -0.5, 0.5, 1.0, 0.0,
-	0.5, -0.5, 0.5, 1.0, 0.0,
-	-0.5, -0.5, 0.5, 0.0, 0.0,
-	-0.5, -0.5, -0.5, 0.0, 1.0,
-
-	-0.5, 0.5, -0.5, 0.0, 1.0,
-	0.5, 0.5, -0.5, 1.0, 1.0,
-	0.5, 0.5, 0.5, 1.0, 0.0,
-	0.5, 0.5, 0.5, 1.0, 0.0,
-	-0.5, 0.5, 0.5, 0.0, 0.0,
-	-0.5, 0.5, -0.5, 0.0, 1.0,
 }
@@ -0,0 +1,79 @@
+// Package framebuffer wraps off-screen render targets: a framebuffer
+// object with a sampleable color texture and a depth-stencil
+// renderbuffer, the combination most post-processing passes want.
+package framebuffer
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// FBO is a framebuffer with one color texture attachment and a combined
+// depth-stencil renderbuffer attachment.
+type FBO struct {
+	handle       uint32
+	colorTexture uint32
+	depthStencil uint32
+	width        int32
+	height       int32
+}
+
+// NewColorDepth creates an FBO of the given size with an RGB color
+// texture and a depth24-stencil8 renderbuffer.
+func NewColorDepth(w, h int) (*FBO, error) {
+	f := &FBO{width: int32(w), height: int32(h)}
+
+	gl.GenFramebuffers(1, &f.handle)
+	gl.BindFramebuffer(gl.FRAMEBUFFER, f.handle)
+
+	gl.GenTextures(1, &f.colorTexture)
+	gl.BindTexture(gl.TEXTURE_2D, f.colorTexture)
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGB, f.width, f.height, 0, gl.RGB, gl.UNSIGNED_BYTE, nil)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.FramebufferTexture2D(gl.FRAMEBUFFER, gl.COLOR_ATTACHMENT0, gl.TEXTURE_2D, f.colorTexture, 0)
+
+	gl.GenRenderbuffers(1, &f.depthStencil)
+	gl.BindRenderbuffer(gl.RENDERBUFFER, f.depthStencil)
+	gl.RenderbufferStorage(gl.RENDERBUFFER, gl.DEPTH24_STENCIL8, f.width, f.height)
+	gl.FramebufferRenderbuffer(gl.FRAMEBUFFER, gl.DEPTH_STENCIL_ATTACHMENT, gl.RENDERBUFFER, f.depthStencil)
+
+	if status := gl.CheckFramebufferStatus(gl.FRAMEBUFFER); status != gl.FRAMEBUFFER_COMPLETE {
+		gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+		f.Delete()
+		return nil, fmt.Errorf("framebuffer: incomplete, status 0x%x", status)
+	}
+
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	return f, nil
+}
+
+// Bind makes f the active render target.
+func (f *FBO) Bind() {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, f.handle)
+	gl.Viewport(0, 0, f.width, f.height)
+}
+
+// Unbind restores the default framebuffer (the window).
+func Unbind(windowWidth, windowHeight int) {
+	gl.BindFramebuffer(gl.FRAMEBUFFER, 0)
+	gl.Viewport(0, 0, int32(windowWidth), int32(windowHeight))
+}
+
+// ColorTexture returns the GL name of f's color attachment, suitable for
+// sampling in a post-processing shader.
+func (f *FBO) ColorTexture() uint32 {
+	return f.colorTexture
+}
+
+// Width and Height return the size the FBO was created with.
+func (f *FBO) Width() int  { return int(f.width) }
+func (f *FBO) Height() int { return int(f.height) }
+
+// Delete releases the FBO's GL objects.
+func (f *FBO) Delete() {
+	gl.DeleteFramebuffers(1, &f.handle)
+	gl.DeleteTextures(1, &f.colorTexture)
+	gl.DeleteRenderbuffers(1, &f.depthStencil)
+}
@@ -0,0 +1,146 @@
+// Package light holds the light types and the uniform-upload plumbing
+// for Blinn-Phong shading: one directional light, plus arrays of point
+// and spot lights.
+package light
+
+import (
+	"fmt"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/gwowen/go-3D-cube/shader"
+)
+
+// Attenuation is the constant/linear/quadratic falloff used by lights
+// that have a position (point and spot lights).
+type Attenuation struct {
+	Constant  float32
+	Linear    float32
+	Quadratic float32
+}
+
+// DirectionalLight models a light infinitely far away, like the sun:
+// every fragment is lit from the same direction.
+type DirectionalLight struct {
+	Direction mgl32.Vec3
+
+	Ambient  mgl32.Vec3
+	Diffuse  mgl32.Vec3
+	Specular mgl32.Vec3
+}
+
+// PointLight radiates from a position in all directions, dimming with
+// distance according to Attenuation.
+type PointLight struct {
+	Position mgl32.Vec3
+
+	Ambient  mgl32.Vec3
+	Diffuse  mgl32.Vec3
+	Specular mgl32.Vec3
+
+	Attenuation
+}
+
+// SpotLight is a point light narrowed to a cone, soft-edged between the
+// inner and outer cutoff (stored as cosines, as the shader wants them).
+type SpotLight struct {
+	Position  mgl32.Vec3
+	Direction mgl32.Vec3
+
+	Ambient  mgl32.Vec3
+	Diffuse  mgl32.Vec3
+	Specular mgl32.Vec3
+
+	Attenuation
+
+	InnerCutoff float32
+	OuterCutoff float32
+}
+
+// maxPointLights and maxSpotLights must match the array sizes declared
+// in shader.frag.
+const (
+	maxPointLights = 4
+	maxSpotLights  = 4
+)
+
+// Manager owns the lights in a scene and uploads them to a shader
+// program's `dirLight`, `pointLights[N]` and `spotLights[N]` uniforms.
+type Manager struct {
+	Directional *DirectionalLight
+	Points      []PointLight
+	Spots       []SpotLight
+}
+
+// NewManager returns an empty light manager.
+func NewManager() *Manager {
+	return &Manager{}
+}
+
+// Upload pushes every light onto program's uniforms. Call it once per
+// frame after gl.UseProgram.
+func (m *Manager) Upload(program *shader.Program) {
+	if m.Directional != nil {
+		uploadDirectional(program, "dirLight", m.Directional)
+	}
+
+	numPoints := len(m.Points)
+	if numPoints > maxPointLights {
+		numPoints = maxPointLights
+	}
+	setInt(program, "numPointLights", int32(numPoints))
+	for i := 0; i < numPoints; i++ {
+		uploadPoint(program, fmt.Sprintf("pointLights[%d]", i), &m.Points[i])
+	}
+
+	numSpots := len(m.Spots)
+	if numSpots > maxSpotLights {
+		numSpots = maxSpotLights
+	}
+	setInt(program, "numSpotLights", int32(numSpots))
+	for i := 0; i < numSpots; i++ {
+		uploadSpot(program, fmt.Sprintf("spotLights[%d]", i), &m.Spots[i])
+	}
+}
+
+func uploadDirectional(program *shader.Program, name string, l *DirectionalLight) {
+	setVec3(program, name+".direction", l.Direction)
+	setVec3(program, name+".ambient", l.Ambient)
+	setVec3(program, name+".diffuse", l.Diffuse)
+	setVec3(program, name+".specular", l.Specular)
+}
+
+func uploadPoint(program *shader.Program, name string, l *PointLight) {
+	setVec3(program, name+".position", l.Position)
+	setVec3(program, name+".ambient", l.Ambient)
+	setVec3(program, name+".diffuse", l.Diffuse)
+	setVec3(program, name+".specular", l.Specular)
+	setFloat(program, name+".constant", l.Constant)
+	setFloat(program, name+".linear", l.Linear)
+	setFloat(program, name+".quadratic", l.Quadratic)
+}
+
+func uploadSpot(program *shader.Program, name string, l *SpotLight) {
+	setVec3(program, name+".position", l.Position)
+	setVec3(program, name+".direction", l.Direction)
+	setVec3(program, name+".ambient", l.Ambient)
+	setVec3(program, name+".diffuse", l.Diffuse)
+	setVec3(program, name+".specular", l.Specular)
+	setFloat(program, name+".constant", l.Constant)
+	setFloat(program, name+".linear", l.Linear)
+	setFloat(program, name+".quadratic", l.Quadratic)
+	setFloat(program, name+".innerCutoff", l.InnerCutoff)
+	setFloat(program, name+".outerCutoff", l.OuterCutoff)
+}
+
+func setVec3(program *shader.Program, name string, v mgl32.Vec3) {
+	gl.Uniform3f(program.Uniform(name), v[0], v[1], v[2])
+}
+
+func setFloat(program *shader.Program, name string, f float32) {
+	gl.Uniform1f(program.Uniform(name), f)
+}
+
+func setInt(program *shader.Program, name string, i int32) {
+	gl.Uniform1i(program.Uniform(name), i)
+}
@@ -0,0 +1,338 @@
+// Package mesh loads Wavefront .obj/.mtl models into GPU-ready meshes.
+package mesh
+
+import (
+	"bufio"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/jpeg"
+	_ "image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+)
+
+// vertexSize is the stride, in float32s, of the interleaved buffer: a
+// position(3) + uv(2) + normal(3) layout so meshes slot into the same
+// attribute bindings the cube used, plus normals for lighting later on.
+const vertexSize = 8
+
+// Mesh is a loaded model ready to be drawn: a VAO/VBO/EBO triple and an
+// optional diffuse texture pulled from its .mtl material.
+type Mesh struct {
+	vao, vbo, ebo uint32
+	indexCount    int32
+	texture       uint32
+}
+
+// LoadOBJ parses a Wavefront .obj file (and its referenced .mtl, if any)
+// and uploads the result to the GPU.
+func LoadOBJ(path string) (*Mesh, error) {
+	positions, uvs, normals, faces, mtllib, usemtl, err := parseOBJ(path)
+	if err != nil {
+		return nil, fmt.Errorf("mesh: parsing %q: %v", path, err)
+	}
+
+	vertices, indices := buildInterleaved(positions, uvs, normals, faces)
+
+	var texture uint32
+	if mtllib != "" {
+		mtlPath := filepath.Join(filepath.Dir(path), mtllib)
+		diffuse, err := parseMTL(mtlPath, usemtl)
+		if err != nil {
+			return nil, fmt.Errorf("mesh: parsing %q: %v", mtlPath, err)
+		}
+		if diffuse != "" {
+			texPath := filepath.Join(filepath.Dir(path), diffuse)
+			texture, err = LoadTexture(texPath)
+			if err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	m := &Mesh{indexCount: int32(len(indices)), texture: texture}
+
+	gl.GenVertexArrays(1, &m.vao)
+	gl.BindVertexArray(m.vao)
+
+	gl.GenBuffers(1, &m.vbo)
+	gl.BindBuffer(gl.ARRAY_BUFFER, m.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices), gl.STATIC_DRAW)
+
+	gl.GenBuffers(1, &m.ebo)
+	gl.BindBuffer(gl.ELEMENT_ARRAY_BUFFER, m.ebo)
+	gl.BufferData(gl.ELEMENT_ARRAY_BUFFER, len(indices)*4, gl.Ptr(indices), gl.STATIC_DRAW)
+
+	stride := int32(vertexSize * 4)
+	gl.VertexAttribPointer(0, 3, gl.FLOAT, false, stride, gl.PtrOffset(0))
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(1, 2, gl.FLOAT, false, stride, gl.PtrOffset(3*4))
+	gl.EnableVertexAttribArray(1)
+	gl.VertexAttribPointer(2, 3, gl.FLOAT, false, stride, gl.PtrOffset(5*4))
+	gl.EnableVertexAttribArray(2)
+
+	gl.BindVertexArray(0)
+
+	return m, nil
+}
+
+// Draw binds the mesh's VAO and draws it as an indexed triangle list
+// against whatever program is currently bound. Texture and material
+// uniforms are the caller's responsibility (see scene.Material) since
+// the same mesh may be drawn with different materials.
+func (m *Mesh) Draw() {
+	gl.BindVertexArray(m.vao)
+	gl.DrawElements(gl.TRIANGLES, m.indexCount, gl.UNSIGNED_INT, gl.PtrOffset(0))
+	gl.BindVertexArray(0)
+}
+
+// Texture returns the diffuse texture loaded from the mesh's .mtl file,
+// or 0 if it had none.
+func (m *Mesh) Texture() uint32 {
+	return m.texture
+}
+
+// faceVertex indexes into the obj's v/vt/vn lists: 1-based, 0 meaning
+// "absent". Obj's own optionally-negative, relative indices are
+// resolved to this absolute, positive form as each face is parsed.
+type faceVertex struct {
+	v, vt, vn int
+}
+
+func parseOBJ(path string) (positions []mgl32.Vec3, uvs []mgl32.Vec2, normals []mgl32.Vec3, faces [][]faceVertex, mtllib, usemtl string, err error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, nil, nil, nil, "", "", err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "v":
+			v, err := parseVec3(fields[1:])
+			if err != nil {
+				return nil, nil, nil, nil, "", "", err
+			}
+			positions = append(positions, v)
+		case "vt":
+			u, uerr := strconv.ParseFloat(fields[1], 32)
+			v, verr := strconv.ParseFloat(fields[2], 32)
+			if uerr != nil || verr != nil {
+				return nil, nil, nil, nil, "", "", fmt.Errorf("bad vt line %q", scanner.Text())
+			}
+			uvs = append(uvs, mgl32.Vec2{float32(u), float32(v)})
+		case "vn":
+			n, err := parseVec3(fields[1:])
+			if err != nil {
+				return nil, nil, nil, nil, "", "", err
+			}
+			normals = append(normals, n)
+		case "f":
+			face, err := parseFace(fields[1:], len(positions), len(uvs), len(normals))
+			if err != nil {
+				return nil, nil, nil, nil, "", "", err
+			}
+			faces = append(faces, face)
+		case "mtllib":
+			mtllib = fields[1]
+		case "usemtl":
+			usemtl = fields[1]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, nil, nil, nil, "", "", err
+	}
+
+	return positions, uvs, normals, faces, mtllib, usemtl, nil
+}
+
+func parseVec3(fields []string) (mgl32.Vec3, error) {
+	if len(fields) < 3 {
+		return mgl32.Vec3{}, fmt.Errorf("expected 3 components, got %d", len(fields))
+	}
+	var v mgl32.Vec3
+	for i := 0; i < 3; i++ {
+		f, err := strconv.ParseFloat(fields[i], 32)
+		if err != nil {
+			return mgl32.Vec3{}, err
+		}
+		v[i] = float32(f)
+	}
+	return v, nil
+}
+
+// parseFace splits "f v/vt/vn v/vt/vn ..." into per-vertex indices and
+// fan-triangulates anything beyond a triangle (i.e. quads). vCount,
+// vtCount and vnCount are the number of v/vt/vn entries seen so far, so
+// obj's negative (relative-to-current-end-of-list) indices can be
+// resolved to absolute ones.
+func parseFace(fields []string, vCount, vtCount, vnCount int) ([]faceVertex, error) {
+	verts := make([]faceVertex, 0, len(fields))
+	for _, field := range fields {
+		parts := strings.Split(field, "/")
+		fv := faceVertex{}
+		var err error
+		if fv.v, err = parseIndex(parts, 0, vCount); err != nil {
+			return nil, err
+		}
+		if fv.vt, err = parseIndex(parts, 1, vtCount); err != nil {
+			return nil, err
+		}
+		if fv.vn, err = parseIndex(parts, 2, vnCount); err != nil {
+			return nil, err
+		}
+		verts = append(verts, fv)
+	}
+	return verts, nil
+}
+
+// parseIndex reads the index at position i of an "f" component
+// (v/vt/vn), returning 0 (meaning "absent") if the component is
+// missing. A negative index is relative to the end of the list (obj's
+// "-1 is the most recently defined vertex") and is resolved to an
+// absolute 1-based index against count, the number of entries defined
+// so far.
+func parseIndex(parts []string, i int, count int) (int, error) {
+	if i >= len(parts) || parts[i] == "" {
+		return 0, nil
+	}
+	n, err := strconv.Atoi(parts[i])
+	if err != nil {
+		return 0, fmt.Errorf("bad face index %q: %v", parts[i], err)
+	}
+	if n < 0 {
+		n = count + n + 1
+	}
+	return n, nil
+}
+
+// buildInterleaved fan-triangulates each face and flattens the obj's
+// separate v/vt/vn lists into the pos+uv+normal buffer the shader wants,
+// deduping so each unique v/vt/vn triple gets a single vertex shared by
+// every index that references it.
+func buildInterleaved(positions []mgl32.Vec3, uvs []mgl32.Vec2, normals []mgl32.Vec3, faces [][]faceVertex) ([]float32, []uint32) {
+	var vertices []float32
+	var indices []uint32
+	seen := make(map[faceVertex]uint32)
+
+	emit := func(fv faceVertex) {
+		if idx, ok := seen[fv]; ok {
+			indices = append(indices, idx)
+			return
+		}
+
+		pos := mgl32.Vec3{}
+		if fv.v > 0 && fv.v <= len(positions) {
+			pos = positions[fv.v-1]
+		}
+		uv := mgl32.Vec2{}
+		if fv.vt > 0 && fv.vt <= len(uvs) {
+			uv = uvs[fv.vt-1]
+		}
+		normal := mgl32.Vec3{}
+		if fv.vn > 0 && fv.vn <= len(normals) {
+			normal = normals[fv.vn-1]
+		}
+
+		idx := uint32(len(vertices) / vertexSize)
+		vertices = append(vertices, pos[0], pos[1], pos[2], uv[0], uv[1], normal[0], normal[1], normal[2])
+		indices = append(indices, idx)
+		seen[fv] = idx
+	}
+
+	for _, face := range faces {
+		// fan triangulation: (0,1,2), (0,2,3), (0,3,4), ...
+		for i := 1; i+1 < len(face); i++ {
+			emit(face[0])
+			emit(face[i])
+			emit(face[i+1])
+		}
+	}
+
+	return vertices, indices
+}
+
+// parseMTL returns the diffuse map (map_Kd) filename for the named
+// material, or the first material's if name is empty.
+func parseMTL(path, name string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	var current, diffuse string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		switch fields[0] {
+		case "newmtl":
+			current = fields[1]
+			if name == "" {
+				name = current
+			}
+		case "map_Kd":
+			if name == "" || current == name {
+				diffuse = fields[len(fields)-1]
+			}
+		}
+	}
+	return diffuse, scanner.Err()
+}
+
+// LoadTexture reads an image file from disk and uploads it as a
+// 2D texture, returning its GL texture name.
+func LoadTexture(file string) (uint32, error) {
+	imgFile, err := os.Open(file)
+	if err != nil {
+		return 0, fmt.Errorf("texture %q not found: %v", file, err)
+	}
+	defer imgFile.Close()
+
+	img, _, err := image.Decode(imgFile)
+	if err != nil {
+		return 0, err
+	}
+
+	rgba := image.NewRGBA(img.Bounds())
+	if rgba.Stride != rgba.Rect.Size().X*4 {
+		return 0, fmt.Errorf("unsupported stride")
+	}
+	draw.Draw(rgba, rgba.Bounds(), img, image.Point{0, 0}, draw.Src)
+
+	var texture uint32
+	gl.GenTextures(1, &texture)
+	gl.ActiveTexture(gl.TEXTURE0)
+	gl.BindTexture(gl.TEXTURE_2D, texture)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.LINEAR)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_S, gl.CLAMP_TO_EDGE)
+	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
+	gl.TexImage2D(
+		gl.TEXTURE_2D,
+		0,
+		gl.RGBA,
+		int32(rgba.Rect.Size().X),
+		int32(rgba.Rect.Size().Y),
+		0,
+		gl.RGBA,
+		gl.UNSIGNED_BYTE,
+		gl.Ptr(rgba.Pix))
+
+	return texture, nil
+}
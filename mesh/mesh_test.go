@@ -0,0 +1,51 @@
+package mesh
+
+import "testing"
+
+func TestParseIndex(t *testing.T) {
+	tests := []struct {
+		name  string
+		parts []string
+		i     int
+		count int
+		want  int
+	}{
+		{name: "absent", parts: []string{"1"}, i: 1, count: 5, want: 0},
+		{name: "empty", parts: []string{"1", ""}, i: 1, count: 5, want: 0},
+		{name: "positive 1-based", parts: []string{"3"}, i: 0, count: 5, want: 3},
+		{name: "negative relative to count", parts: []string{"-1"}, i: 0, count: 5, want: 5},
+		{name: "negative a few back", parts: []string{"-3"}, i: 0, count: 5, want: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseIndex(tt.parts, tt.i, tt.count)
+			if err != nil {
+				t.Fatalf("parseIndex(%v, %d, %d) returned error: %v", tt.parts, tt.i, tt.count, err)
+			}
+			if got != tt.want {
+				t.Errorf("parseIndex(%v, %d, %d) = %d, want %d", tt.parts, tt.i, tt.count, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseFaceNegativeIndices(t *testing.T) {
+	// "f -3/-3/-3 -2/-2/-2 -1/-1/-1" against 3 previously-defined
+	// v/vt/vn entries should resolve to the same triangle as "1/1/1
+	// 2/2/2 3/3/3".
+	got, err := parseFace([]string{"-3/-3/-3", "-2/-2/-2", "-1/-1/-1"}, 3, 3, 3)
+	if err != nil {
+		t.Fatalf("parseFace returned error: %v", err)
+	}
+
+	want := []faceVertex{{v: 1, vt: 1, vn: 1}, {v: 2, vt: 2, vn: 2}, {v: 3, vt: 3, vn: 3}}
+	if len(got) != len(want) {
+		t.Fatalf("parseFace returned %d vertices, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("vertex %d = %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
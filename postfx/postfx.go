@@ -0,0 +1,161 @@
+// Package postfx draws a full-screen quad sampling an off-screen color
+// buffer through a post-processing shader, ping-ponging between two
+// framebuffers so several effects can be chained in sequence.
+package postfx
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/gwowen/go-3D-cube/framebuffer"
+	"github.com/gwowen/go-3D-cube/shader"
+)
+
+// Effect selects which branch of post.frag runs for a Pass.
+const (
+	EffectNone = iota
+	EffectGrayscale
+	EffectInvert
+	EffectKernel
+	EffectGammaCorrect
+)
+
+// 3x3 convolution kernels for EffectKernel passes. BlurKernel and
+// SharpenKernel sum to 1, so they keep the image's overall brightness;
+// EdgeDetectKernel sums to 0, so flat regions go black and edges remain.
+var (
+	BlurKernel = mgl32.Mat3{
+		1.0 / 16, 2.0 / 16, 1.0 / 16,
+		2.0 / 16, 4.0 / 16, 2.0 / 16,
+		1.0 / 16, 2.0 / 16, 1.0 / 16,
+	}
+	SharpenKernel = mgl32.Mat3{
+		-1, -1, -1,
+		-1, 9, -1,
+		-1, -1, -1,
+	}
+	EdgeDetectKernel = mgl32.Mat3{
+		1, 1, 1,
+		1, -8, 1,
+		1, 1, 1,
+	}
+)
+
+// Pass is one step of a Chain: which effect to apply and the parameters
+// it reads (only the ones relevant to Effect are used).
+type Pass struct {
+	Effect       int32
+	Kernel       mgl32.Mat3
+	KernelOffset float32
+	Gamma        float32
+}
+
+// Chain runs a sequence of Passes over a source texture, ping-ponging
+// between two off-screen FBOs so the output of one pass becomes the
+// input of the next, and draws the final result to the window.
+type Chain struct {
+	program    *shader.Program
+	quad       *quad
+	ping, pong *framebuffer.FBO
+}
+
+// NewChain allocates the two ping-pong FBOs a chain needs, sized to
+// match the window.
+func NewChain(program *shader.Program, width, height int) (*Chain, error) {
+	ping, err := framebuffer.NewColorDepth(width, height)
+	if err != nil {
+		return nil, err
+	}
+	pong, err := framebuffer.NewColorDepth(width, height)
+	if err != nil {
+		ping.Delete()
+		return nil, err
+	}
+
+	return &Chain{program: program, quad: newQuad(), ping: ping, pong: pong}, nil
+}
+
+// Draw runs passes over sourceTexture in order and presents the result
+// to the default framebuffer (the window, windowWidth x windowHeight).
+// With no passes, sourceTexture is blitted through unmodified.
+func (c *Chain) Draw(sourceTexture uint32, passes []Pass, windowWidth, windowHeight int) {
+	if len(passes) == 0 {
+		passes = []Pass{{Effect: EffectNone}}
+	}
+
+	gl.Disable(gl.DEPTH_TEST)
+	gl.UseProgram(c.program.Handle())
+
+	targets := [2]*framebuffer.FBO{c.ping, c.pong}
+	read := sourceTexture
+
+	for i, pass := range passes {
+		last := i == len(passes)-1
+		if last {
+			framebuffer.Unbind(windowWidth, windowHeight)
+		} else {
+			target := targets[i%2]
+			target.Bind()
+			gl.Clear(gl.COLOR_BUFFER_BIT)
+		}
+
+		gl.ActiveTexture(gl.TEXTURE0)
+		gl.BindTexture(gl.TEXTURE_2D, read)
+		gl.Uniform1i(c.program.Uniform("screenTexture"), 0)
+		gl.Uniform1i(c.program.Uniform("effect"), pass.Effect)
+		gl.UniformMatrix3fv(c.program.Uniform("kernel"), 1, false, &pass.Kernel[0])
+		gl.Uniform1f(c.program.Uniform("kernelOffset"), pass.KernelOffset)
+		gl.Uniform1f(c.program.Uniform("gamma"), pass.Gamma)
+
+		c.quad.draw()
+
+		if !last {
+			read = targets[i%2].ColorTexture()
+		}
+	}
+
+	gl.Enable(gl.DEPTH_TEST)
+}
+
+// Delete releases the chain's off-screen FBOs.
+func (c *Chain) Delete() {
+	c.ping.Delete()
+	c.pong.Delete()
+}
+
+// quad is a clip-space fullscreen triangle strip: position(2) + uv(2).
+type quad struct {
+	vao uint32
+	vbo uint32
+}
+
+func newQuad() *quad {
+	vertices := []float32{
+		// x, y, u, v
+		-1, 1, 0, 1,
+		-1, -1, 0, 0,
+		1, 1, 1, 1,
+		1, -1, 1, 0,
+	}
+
+	q := &quad{}
+	gl.GenVertexArrays(1, &q.vao)
+	gl.BindVertexArray(q.vao)
+
+	gl.GenBuffers(1, &q.vbo)
+	gl.BindBuffer(gl.ARRAY_BUFFER, q.vbo)
+	gl.BufferData(gl.ARRAY_BUFFER, len(vertices)*4, gl.Ptr(vertices), gl.STATIC_DRAW)
+
+	gl.VertexAttribPointer(0, 2, gl.FLOAT, false, 4*4, gl.PtrOffset(0))
+	gl.EnableVertexAttribArray(0)
+	gl.VertexAttribPointer(1, 2, gl.FLOAT, false, 4*4, gl.PtrOffset(2*4))
+	gl.EnableVertexAttribArray(1)
+
+	gl.BindVertexArray(0)
+	return q
+}
+
+func (q *quad) draw() {
+	gl.BindVertexArray(q.vao)
+	gl.DrawArrays(gl.TRIANGLE_STRIP, 0, 4)
+	gl.BindVertexArray(0)
+}
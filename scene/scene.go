@@ -0,0 +1,172 @@
+// Package scene provides a small scene graph so more than one mesh can
+// be positioned, parented and drawn without main() hand-rolling uniform
+// plumbing for every object.
+package scene
+
+import (
+	"github.com/go-gl/gl/v3.3-core/gl"
+	"github.com/go-gl/mathgl/mgl32"
+	"github.com/gwowen/go-3D-cube/mesh"
+	"github.com/gwowen/go-3D-cube/shader"
+)
+
+// Material pairs a shader program with the texture maps and uniform
+// values a node should be drawn with. Uniforms holds anything beyond
+// the model/view/projection/material values the scene sets
+// automatically; supported value types are float32, mgl32.Vec3,
+// mgl32.Vec4 and mgl32.Mat4.
+type Material struct {
+	Program *shader.Program
+
+	// DiffuseMap and SpecularMap are sampled by the fragment shader as
+	// material.diffuse and material.specular. DiffuseMap is usually
+	// just the mesh's own Mesh.Texture(); SpecularMap may be left 0.
+	DiffuseMap  uint32
+	SpecularMap uint32
+	Shininess   float32
+
+	Uniforms map[string]interface{}
+}
+
+// Node is one entry in the scene graph: a mesh drawn with a material at
+// a transform relative to its parent.
+type Node struct {
+	Mesh      *mesh.Mesh
+	Material  *Material
+	Transform mgl32.Mat4
+
+	Children []*Node
+}
+
+// NewNode creates a node with an identity transform. Mesh and Material
+// may be nil for a pure grouping node.
+func NewNode(m *mesh.Mesh, material *Material) *Node {
+	return &Node{Mesh: m, Material: material, Transform: mgl32.Ident4()}
+}
+
+// AddChild attaches child to n.
+func (n *Node) AddChild(child *Node) {
+	n.Children = append(n.Children, child)
+}
+
+// Scene is a forest of Nodes plus the draws needed to render them.
+type Scene struct {
+	Roots []*Node
+}
+
+// NewScene returns an empty scene.
+func NewScene() *Scene {
+	return &Scene{}
+}
+
+// Add appends root to the scene's top-level nodes.
+func (s *Scene) Add(root *Node) {
+	s.Roots = append(s.Roots, root)
+}
+
+// drawable is a node flattened to its world matrix, ready to be grouped
+// and drawn.
+type drawable struct {
+	node  *Node
+	world mgl32.Mat4
+}
+
+// Draw walks the scene, groups nodes by shader program to minimize
+// gl.UseProgram calls, and draws each with view/projection bound along
+// with its own model matrix and material uniforms.
+func (s *Scene) Draw(view, projection mgl32.Mat4) {
+	var items []drawable
+	for _, root := range s.Roots {
+		flatten(root, mgl32.Ident4(), &items)
+	}
+
+	groups := make(map[*shader.Program][]drawable)
+	var order []*shader.Program
+	for _, item := range items {
+		if item.node.Mesh == nil || item.node.Material == nil {
+			continue
+		}
+		program := item.node.Material.Program
+		if _, seen := groups[program]; !seen {
+			order = append(order, program)
+		}
+		groups[program] = append(groups[program], item)
+	}
+
+	for _, program := range order {
+		gl.UseProgram(program.Handle())
+		setMat4(program, "view", view)
+		setMat4(program, "projection", projection)
+
+		for _, item := range groups[program] {
+			mat := item.node.Material
+			setMat4(program, "model", item.world)
+			setMat3(program, "normalMatrix", normalMatrix(item.world))
+
+			if mat.DiffuseMap != 0 {
+				gl.ActiveTexture(gl.TEXTURE0)
+				gl.BindTexture(gl.TEXTURE_2D, mat.DiffuseMap)
+				gl.Uniform1i(program.Uniform("material.diffuse"), 0)
+			}
+			if mat.SpecularMap != 0 {
+				gl.ActiveTexture(gl.TEXTURE1)
+				gl.BindTexture(gl.TEXTURE_2D, mat.SpecularMap)
+				gl.Uniform1i(program.Uniform("material.specular"), 1)
+			}
+			setFloat(program, "material.shininess", mat.Shininess)
+
+			for name, value := range mat.Uniforms {
+				setUniform(program, name, value)
+			}
+
+			item.node.Mesh.Draw()
+		}
+	}
+}
+
+// flatten walks node and its children depth-first, composing world
+// matrices as it goes and appending every drawable node to items.
+func flatten(node *Node, parentWorld mgl32.Mat4, items *[]drawable) {
+	world := parentWorld.Mul4(node.Transform)
+
+	if node.Mesh != nil && node.Material != nil {
+		*items = append(*items, drawable{node: node, world: world})
+	}
+
+	for _, child := range node.Children {
+		flatten(child, world, items)
+	}
+}
+
+func setMat4(program *shader.Program, name string, m mgl32.Mat4) {
+	gl.UniformMatrix4fv(program.Uniform(name), 1, false, &m[0])
+}
+
+func setMat3(program *shader.Program, name string, m mgl32.Mat3) {
+	gl.UniformMatrix3fv(program.Uniform(name), 1, false, &m[0])
+}
+
+func setFloat(program *shader.Program, name string, f float32) {
+	gl.Uniform1f(program.Uniform(name), f)
+}
+
+// normalMatrix is the inverse-transpose of the model matrix's upper 3x3,
+// needed so normals stay perpendicular to surfaces under non-uniform
+// scaling.
+func normalMatrix(model mgl32.Mat4) mgl32.Mat3 {
+	return model.Mat3().Inv().Transpose()
+}
+
+func setUniform(program *shader.Program, name string, value interface{}) {
+	loc := program.Uniform(name)
+	switch v := value.(type) {
+	case float32:
+		gl.Uniform1f(loc, v)
+	case mgl32.Vec3:
+		gl.Uniform3f(loc, v[0], v[1], v[2])
+	case mgl32.Vec4:
+		gl.Uniform4f(loc, v[0], v[1], v[2], v[3])
+	case mgl32.Mat4:
+		gl.UniformMatrix4fv(loc, 1, false, &v[0])
+	}
+}
@@ -0,0 +1,285 @@
+// Package shader owns GL shader programs, hot-reloading them from disk
+// with fsnotify and caching their uniform locations.
+package shader
+
+import (
+	"fmt"
+	"io/ioutil"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/go-gl/gl/v3.3-core/gl"
+)
+
+// Program is a linked shader program that reloads itself when its
+// source files change on disk. Reloading touches the GL context, so it
+// only ever happens inside Manager.Poll, called from the render loop's
+// locked OS thread.
+type Program struct {
+	mu       sync.RWMutex
+	handle   uint32
+	uniforms map[string]int32
+
+	vertPath string
+	fragPath string
+	geomPath string
+}
+
+// Handle returns the program's current, working GL handle.
+func (p *Program) Handle() uint32 {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.handle
+}
+
+// Uniform returns the cached location of a uniform, looking it up (and
+// caching the result) on first use so the render loop doesn't pay for
+// gl.GetUniformLocation every frame.
+func (p *Program) Uniform(name string) int32 {
+	p.mu.RLock()
+	loc, ok := p.uniforms[name]
+	handle := p.handle
+	p.mu.RUnlock()
+	if ok {
+		return loc
+	}
+
+	loc = gl.GetUniformLocation(handle, gl.Str(name+"\x00"))
+
+	p.mu.Lock()
+	p.uniforms[name] = loc
+	p.mu.Unlock()
+
+	return loc
+}
+
+// reload recompiles the program from its source files. On failure it
+// logs the compile/link error and leaves the previous working handle
+// bound, so a typo in a shader doesn't take down the window. On success
+// it swaps in the new handle and drops the stale uniform cache, since
+// locations aren't guaranteed to survive relinking.
+func (p *Program) reload() {
+	handle, err := compileProgram(p.vertPath, p.fragPath, p.geomPath)
+	if err != nil {
+		log.Printf("shader: reload of %s/%s failed, keeping previous program: %v", p.vertPath, p.fragPath, err)
+		return
+	}
+
+	p.mu.Lock()
+	old := p.handle
+	p.handle = handle
+	p.uniforms = make(map[string]int32)
+	p.mu.Unlock()
+
+	gl.DeleteProgram(old)
+}
+
+// Manager owns every Program in use and watches their source files,
+// recompiling a program whenever one of its files is written.
+type Manager struct {
+	watcher *fsnotify.Watcher
+
+	mu       sync.Mutex
+	programs map[string][]*Program // source path -> programs built from it
+
+	// dirty carries programs the watcher goroutine has flagged for
+	// reload. GL is only current on the render loop's locked OS thread,
+	// so the watcher goroutine must not call reload itself; it just
+	// queues programs here for Poll to pick up.
+	dirty chan *Program
+}
+
+// NewManager starts a filesystem watcher. Call Close when done with it.
+func NewManager() (*Manager, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Manager{
+		watcher:  watcher,
+		programs: make(map[string][]*Program),
+		dirty:    make(chan *Program, 16),
+	}
+	go m.watch()
+	return m, nil
+}
+
+// Poll reloads any programs the watcher has flagged since the last
+// call. Call it once per frame from the render loop, after
+// runtime.LockOSThread has pinned it to the thread holding the GL
+// context.
+func (m *Manager) Poll() {
+	for {
+		select {
+		case p := <-m.dirty:
+			p.reload()
+		default:
+			return
+		}
+	}
+}
+
+// Load compiles a vertex+fragment program (and, if geomPath is given, a
+// geometry stage too) and starts watching its source files.
+func (m *Manager) Load(vertPath, fragPath string, geomPath ...string) (*Program, error) {
+	var geom string
+	if len(geomPath) > 0 {
+		geom = geomPath[0]
+	}
+
+	handle, err := compileProgram(vertPath, fragPath, geom)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &Program{
+		handle:   handle,
+		uniforms: make(map[string]int32),
+		vertPath: vertPath,
+		fragPath: fragPath,
+		geomPath: geom,
+	}
+
+	m.track(vertPath, p)
+	m.track(fragPath, p)
+	if geom != "" {
+		m.track(geom, p)
+	}
+
+	return p, nil
+}
+
+func (m *Manager) track(path string, p *Program) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, watched := m.programs[path]; !watched {
+		if err := m.watcher.Add(path); err != nil {
+			log.Printf("shader: could not watch %s: %v", path, err)
+		}
+	}
+	m.programs[path] = append(m.programs[path], p)
+}
+
+// watch reacts to fsnotify events by queuing every program that depends
+// on the written file onto m.dirty. It must not touch GL itself: this
+// goroutine runs on whatever OS thread the Go runtime schedules it on,
+// not the one holding the GL context.
+func (m *Manager) watch() {
+	for {
+		select {
+		case event, ok := <-m.watcher.Events:
+			if !ok {
+				return
+			}
+			if event.Op&(fsnotify.Write|fsnotify.Create) == 0 {
+				continue
+			}
+
+			m.mu.Lock()
+			affected := append([]*Program(nil), m.programs[event.Name]...)
+			m.mu.Unlock()
+
+			for _, p := range affected {
+				m.dirty <- p
+			}
+		case err, ok := <-m.watcher.Errors:
+			if !ok {
+				return
+			}
+			log.Printf("shader: watcher error: %v", err)
+		}
+	}
+}
+
+// Close stops the filesystem watcher.
+func (m *Manager) Close() error {
+	return m.watcher.Close()
+}
+
+// compileProgram reads, compiles and links a vertex+fragment (and
+// optional geometry) shader program from source files.
+func compileProgram(vertPath, fragPath, geomPath string) (uint32, error) {
+	vertSource, err := ioutil.ReadFile(vertPath)
+	if err != nil {
+		return 0, err
+	}
+	fragSource, err := ioutil.ReadFile(fragPath)
+	if err != nil {
+		return 0, err
+	}
+
+	vertShader, err := compileShader(string(vertSource), gl.VERTEX_SHADER)
+	if err != nil {
+		return 0, fmt.Errorf("vertex shader: %v", err)
+	}
+	defer gl.DeleteShader(vertShader)
+
+	fragShader, err := compileShader(string(fragSource), gl.FRAGMENT_SHADER)
+	if err != nil {
+		return 0, fmt.Errorf("fragment shader: %v", err)
+	}
+	defer gl.DeleteShader(fragShader)
+
+	program := gl.CreateProgram()
+	gl.AttachShader(program, vertShader)
+	gl.AttachShader(program, fragShader)
+
+	if geomPath != "" {
+		geomSource, err := ioutil.ReadFile(geomPath)
+		if err != nil {
+			return 0, err
+		}
+		geomShader, err := compileShader(string(geomSource), gl.GEOMETRY_SHADER)
+		if err != nil {
+			return 0, fmt.Errorf("geometry shader: %v", err)
+		}
+		defer gl.DeleteShader(geomShader)
+		gl.AttachShader(program, geomShader)
+	}
+
+	gl.LinkProgram(program)
+
+	var status int32
+	gl.GetProgramiv(program, gl.LINK_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetProgramiv(program, gl.INFO_LOG_LENGTH, &logLength)
+
+		infoLog := strings.Repeat("\x00", int(logLength+1))
+		gl.GetProgramInfoLog(program, logLength, nil, gl.Str(infoLog))
+
+		gl.DeleteProgram(program)
+		return 0, fmt.Errorf("failed to link program: %v", infoLog)
+	}
+
+	return program, nil
+}
+
+// compileShader compiles a single shader stage from source.
+func compileShader(source string, shaderType uint32) (uint32, error) {
+	shader := gl.CreateShader(shaderType)
+
+	csources, free := gl.Strs(source)
+	gl.ShaderSource(shader, 1, csources, nil)
+	free()
+	gl.CompileShader(shader)
+
+	var status int32
+	gl.GetShaderiv(shader, gl.COMPILE_STATUS, &status)
+	if status == gl.FALSE {
+		var logLength int32
+		gl.GetShaderiv(shader, gl.INFO_LOG_LENGTH, &logLength)
+
+		infoLog := strings.Repeat("\x00", int(logLength+1))
+		gl.GetShaderInfoLog(shader, logLength, nil, gl.Str(infoLog))
+
+		gl.DeleteShader(shader)
+		return 0, fmt.Errorf("failed to compile: %v", infoLog)
+	}
+
+	return shader, nil
+}